@@ -9,6 +9,7 @@ import (
 	"github.com/kanryu/mado/io/event"
 	"github.com/kanryu/mado/io/input"
 	"github.com/kanryu/mado/io/key"
+	"github.com/kanryu/mado/io/pointer"
 	"github.com/kanryu/mado/io/system"
 )
 
@@ -17,12 +18,26 @@ var _ mado.Callbacks = (*callbacks)(nil)
 type callbacks struct {
 	w          *Window
 	d          mado.Driver
+	ctx        mado.Context
 	busy       bool
 	waitEvents []event.Event
+
+	// priorityEvents jumps the waitEvents queue: system.DestroyEvent and
+	// key.FocusEvent must never be starved by a sustained stream of
+	// coalesced pointer/wakeup(resize)/IME events (see enqueue).
+	priorityEvents []event.Event
+	// pointerMoveHistory keeps recent timestamps for coalesced
+	// pointer.Move events per pointer, for gesture recognizers that need
+	// more than the latest sample (see enqueue).
+	pointerMoveHistory map[pointer.ID][]time.Duration
+	// eventQueueHighWater overrides defaultEventQueueHighWater when set
+	// via WithEventQueueHighWater.
+	eventQueueHighWater int
 }
 
 func (c *callbacks) SetWindow(w *Window) {
 	c.w = w
+	c.ApplyEventQueueOptions(DefaultEventQueueOptions...)
 }
 
 func (c *callbacks) SetDriver(d mado.Driver) {
@@ -30,24 +45,35 @@ func (c *callbacks) SetDriver(d mado.Driver) {
 	var wakeup func()
 	if d != nil {
 		wakeup = d.Wakeup
+	} else if c.ctx != nil {
+		// The driver is tearing down: release our Surface along with it
+		// so the backend can drop its refcount on the shared Display
+		// (see d3d11Display.Release) instead of leaking it until the
+		// process exits.
+		c.ctx.Release()
+		c.ctx = nil
 	}
 	c.w.WakeupFuncs <- wakeup
 }
 
+// SetContext records the GPU context the active driver created for this
+// window, so introspection (see ReadDebugInfo) and other callers that need
+// the live backend object don't have to thread it through separately.
+func (c *callbacks) SetContext(ctx mado.Context) {
+	c.ctx = ctx
+}
+
 func (c *callbacks) Event(e event.Event) bool {
 	if c.d == nil {
 		panic("event while no driver active")
 	}
-	c.waitEvents = append(c.waitEvents, e)
+	c.enqueue(e)
 	if c.busy {
 		return true
 	}
 	c.busy = true
 	var handled bool
-	for len(c.waitEvents) > 0 {
-		e := c.waitEvents[0]
-		copy(c.waitEvents, c.waitEvents[1:])
-		c.waitEvents = c.waitEvents[:len(c.waitEvents)-1]
+	for e, ok := c.dequeue(); ok; e, ok = c.dequeue() {
 		handled = c.w.ProcessEvent(c.d, e)
 	}
 	c.busy = false
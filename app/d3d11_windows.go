@@ -5,6 +5,7 @@ package app
 import (
 	"fmt"
 	"runtime"
+	"sync"
 	"unsafe"
 
 	"github.com/kanryu/mado"
@@ -12,14 +13,134 @@ import (
 	"github.com/kanryu/mado/internal/d3d11"
 )
 
-type d3d11Context struct {
-	win *window
+// d3d11Display owns the single D3D11 device and immediate context shared
+// by every window's d3d11Surface. It's created once per process, by
+// whichever window opens first, and released once the last Surface
+// referencing it is gone.
+type d3d11Display struct {
 	dev *d3d11.Device
 	ctx *d3d11.DeviceContext
+	cfg d3d11Config
+
+	refs int
+}
+
+var (
+	d3d11SharedMu sync.Mutex
+	d3d11Shared   *d3d11Display
+)
+
+// acquireD3D11Display returns the shared d3d11Display, creating it (per
+// cfg) if this is the first caller, and otherwise incrementing its
+// refcount. Every successful call must be matched by a Release().
+func acquireD3D11Display(cfg d3d11Config) (*d3d11Display, error) {
+	d3d11SharedMu.Lock()
+	defer d3d11SharedMu.Unlock()
+	if d3d11Shared != nil {
+		d3d11Shared.refs++
+		return d3d11Shared, nil
+	}
+	var flags uint32
+	if cfg.debug {
+		flags |= d3d11.CREATE_DEVICE_DEBUG
+	}
+	dev, ctx, _, err := d3d11.CreateDevice(
+		d3d11.DRIVER_TYPE_HARDWARE,
+		flags,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("NewContext: %v", err)
+	}
+	d3d11Shared = &d3d11Display{dev: dev, ctx: ctx, cfg: cfg, refs: 1}
+	return d3d11Shared, nil
+}
+
+var _ mado.GraphicsDisplay = (*d3d11Display)(nil)
+
+// Configs implements mado.GraphicsDisplay. Every Surface opened against d
+// uses the config d was created with, so that is the only one listed.
+func (d *d3d11Display) Configs() []mado.GraphicsConfig {
+	return []mado.GraphicsConfig{d.cfg}
+}
+
+// Release implements mado.GraphicsDisplay.
+func (d *d3d11Display) Release() {
+	d3d11SharedMu.Lock()
+	defer d3d11SharedMu.Unlock()
+	d.refs--
+	if d.refs > 0 {
+		return
+	}
+	d3d11.IUnknownRelease(unsafe.Pointer(d.ctx), d.ctx.Vtbl.Release)
+	d3d11.IUnknownRelease(unsafe.Pointer(d.dev), d.dev.Vtbl.Release)
+	if debugDirectX {
+		d3d11.ReportLiveObjects()
+	}
+	d3d11Shared = nil
+}
+
+var _ mado.GraphicsConfig = d3d11Config{}
+
+// d3d11Config selects the device-creation options a window's Surface is
+// built with. D3D11 has no real feature-level negotiation surface beyond
+// what CreateDevice already picks for DRIVER_TYPE_HARDWARE, so today this
+// only carries the debug-layer toggle, queryable/selectable before a
+// window opens a Surface against the shared Display.
+type d3d11Config struct {
+	debug bool
+}
+
+func (c d3d11Config) String() string {
+	if c.debug {
+		return "d3d11 (debug layer)"
+	}
+	return "d3d11"
+}
+
+// d3d11Configs lists the configs D3D11Configs offers. There's no feature
+// level negotiation to probe here, so this is just the debug/non-debug
+// choice, statically.
+var d3d11Configs = []d3d11Config{{debug: false}, {debug: true}}
+
+// selectedD3D11Config is what acquireD3D11Display uses the first time it
+// creates the shared Display. Set it via SetD3D11Config before opening a
+// window to pre-flight which one gets used.
+var selectedD3D11Config = d3d11Config{debug: debugDirectX}
+
+// D3D11Configs returns the configs available for a window's D3D11
+// Surface, so a caller can pick one via SetD3D11Config before opening a
+// window, while no shared Display exists yet.
+func D3D11Configs() []mado.GraphicsConfig {
+	out := make([]mado.GraphicsConfig, len(d3d11Configs))
+	for i, c := range d3d11Configs {
+		out[i] = c
+	}
+	return out
+}
+
+// SetD3D11Config selects cfg (from D3D11Configs) for the next time the
+// shared Display is created. It has no effect while a Display exists:
+// Surfaces opened then share that Display's config.
+func SetD3D11Config(cfg mado.GraphicsConfig) {
+	if c, ok := cfg.(d3d11Config); ok {
+		selectedD3D11Config = c
+	}
+}
+
+var _ mado.Surface = (*d3d11Surface)(nil)
+
+// d3d11Surface is the per-window half of what used to be a monolithic
+// d3d11Context: a swapchain and render target bound to one window, backed
+// by the process-wide shared d3d11Display. It implements mado.Surface.
+type d3d11Surface struct {
+	win     *window
+	display *d3d11Display
 
 	swchain       *d3d11.IDXGISwapChain
 	renderTarget  *d3d11.RenderTargetView
 	width, height int
+
+	syncInterval int
 }
 
 const debugDirectX = false
@@ -30,39 +151,37 @@ func init() {
 		name:     "d3d11",
 		initializer: func(w *window) (mado.Context, error) {
 			hwnd, _, _ := w.HWND()
-			var flags uint32
-			if debugDirectX {
-				flags |= d3d11.CREATE_DEVICE_DEBUG
-			}
-			dev, ctx, _, err := d3d11.CreateDevice(
-				d3d11.DRIVER_TYPE_HARDWARE,
-				flags,
-			)
+			display, err := acquireD3D11Display(selectedD3D11Config)
 			if err != nil {
-				return nil, fmt.Errorf("NewContext: %v", err)
+				mado.ReportError(mado.ErrorReasonAPIUnavailable, err.Error())
+				return nil, err
 			}
-			swchain, err := d3d11.CreateSwapChain(dev, hwnd)
+			swchain, err := d3d11.CreateSwapChain(display.dev, hwnd)
 			if err != nil {
-				d3d11.IUnknownRelease(unsafe.Pointer(ctx), ctx.Vtbl.Release)
-				d3d11.IUnknownRelease(unsafe.Pointer(dev), dev.Vtbl.Release)
+				display.Release()
+				mado.ReportError(mado.ErrorReasonFormatUnavailable, err.Error())
 				return nil, err
 			}
-			return &d3d11Context{win: w, dev: dev, ctx: ctx, swchain: swchain}, nil
+			surface := &d3d11Surface{win: w, display: display, swchain: swchain}
+			if w.callbacks != nil {
+				w.callbacks.SetContext(surface)
+			}
+			return surface, nil
 		},
 	})
 }
 
-func (c *d3d11Context) API() gpu.API {
-	return gpu.Direct3D11{Device: unsafe.Pointer(c.dev)}
+func (c *d3d11Surface) API() gpu.API {
+	return gpu.Direct3D11{Device: unsafe.Pointer(c.display.dev)}
 }
 
-func (c *d3d11Context) RenderTarget() (gpu.RenderTarget, error) {
+func (c *d3d11Surface) RenderTarget() (gpu.RenderTarget, error) {
 	return gpu.Direct3D11RenderTarget{
 		RenderTarget: unsafe.Pointer(c.renderTarget),
 	}, nil
 }
 
-func (c *d3d11Context) Present() error {
+func (c *d3d11Surface) Present() error {
 	return wrapErr(c.swchain.Present(1, 0))
 }
 
@@ -79,7 +198,7 @@ func wrapErr(err error) error {
 	return err
 }
 
-func (c *d3d11Context) Refresh() error {
+func (c *d3d11Surface) Refresh() error {
 	var width, height int
 	_, width, height = c.win.HWND()
 	if c.renderTarget != nil && width == c.width && height == c.height {
@@ -97,7 +216,7 @@ func (c *d3d11Context) Refresh() error {
 		return err
 	}
 	texture := (*d3d11.Resource)(unsafe.Pointer(backBuffer))
-	renderTarget, err := c.dev.CreateRenderTargetView(texture)
+	renderTarget, err := c.display.dev.CreateRenderTargetView(texture)
 	d3d11.IUnknownRelease(unsafe.Pointer(backBuffer), backBuffer.Vtbl.Release)
 	if err != nil {
 		return err
@@ -106,50 +225,52 @@ func (c *d3d11Context) Refresh() error {
 	return nil
 }
 
-func (c *d3d11Context) Lock() error {
-	c.ctx.OMSetRenderTargets(c.renderTarget, nil)
+func (c *d3d11Surface) Lock() error {
+	c.display.ctx.OMSetRenderTargets(c.renderTarget, nil)
 	return nil
 }
 
-func (c *d3d11Context) Unlock() {}
+func (c *d3d11Surface) Unlock() {}
 
-func (c *d3d11Context) Release() {
+func (c *d3d11Surface) Release() {
 	c.releaseFBO()
 	if c.swchain != nil {
 		d3d11.IUnknownRelease(unsafe.Pointer(c.swchain), c.swchain.Vtbl.Release)
 	}
-	if c.ctx != nil {
-		d3d11.IUnknownRelease(unsafe.Pointer(c.ctx), c.ctx.Vtbl.Release)
-	}
-	if c.dev != nil {
-		d3d11.IUnknownRelease(unsafe.Pointer(c.dev), c.dev.Vtbl.Release)
+	if c.display != nil {
+		c.display.Release()
 	}
-	*c = d3d11Context{}
-	if debugDirectX {
-		d3d11.ReportLiveObjects()
+	*c = d3d11Surface{}
+}
+
+func (c *d3d11Surface) DebugInfo() mado.DebugInfo {
+	adapter, device := c.display.dev.AdapterDesc()
+	return mado.DebugInfo{
+		GraphicsLibrary: mado.GraphicsLibraryDirect3D11,
+		Adapter:         adapter,
+		Device:          device,
 	}
 }
 
-func (c *d3d11Context) releaseFBO() {
+func (c *d3d11Surface) releaseFBO() {
 	if c.renderTarget != nil {
 		d3d11.IUnknownRelease(unsafe.Pointer(c.renderTarget), c.renderTarget.Vtbl.Release)
 		c.renderTarget = nil
 	}
 }
 
-func (c *d3d11Context) MakeCurrentContext() error {
-	// OpenGL contexts are implicit and thread-local. Lock the OS thread.
+func (c *d3d11Surface) MakeCurrentContext() error {
 	runtime.LockOSThread()
 
-	fmt.Println("not implamented")
+	c.display.ctx.OMSetRenderTargets(c.renderTarget, nil)
 	return nil
 }
 
-func (c *d3d11Context) SwapBuffers() error {
-	fmt.Println("not implamented")
-	return nil
+func (c *d3d11Surface) SwapBuffers() error {
+	return wrapErr(c.swchain.Present(c.syncInterval, 0))
 }
 
-func (c *d3d11Context) SwapInterval(interval int) {
-	fmt.Println("not implamented")
+func (c *d3d11Surface) SwapInterval(interval int) {
+	// The interval is an argument to Present, so just remember it here.
+	c.syncInterval = interval
 }
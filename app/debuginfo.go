@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package app
+
+import "github.com/kanryu/mado"
+
+// ReadDebugInfo fills di with introspection data about the graphics
+// backend currently driving w, such as which GraphicsLibrary is active and
+// the adapter/device strings it reports. If the active Context doesn't
+// implement mado.DebugContext, di is reset to the zero value
+// (GraphicsLibraryUnknown).
+func (w *Window) ReadDebugInfo(di *mado.DebugInfo) {
+	*di = mado.DebugInfo{}
+	dc, ok := w.callbacks.ctx.(mado.DebugContext)
+	if !ok {
+		return
+	}
+	*di = dc.DebugInfo()
+}
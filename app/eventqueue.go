@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package app
+
+import (
+	"log"
+	"time"
+
+	"github.com/kanryu/mado"
+	"github.com/kanryu/mado/io/event"
+	"github.com/kanryu/mado/io/key"
+	"github.com/kanryu/mado/io/pointer"
+	"github.com/kanryu/mado/io/system"
+)
+
+// defaultEventQueueHighWater is the number of buffered input events after
+// which callbacks.enqueue starts dropping further low-priority events
+// instead of growing the queue without bound, unless overridden by
+// WithEventQueueHighWater.
+const defaultEventQueueHighWater = 4096
+
+// EventQueueOption configures a window's bounded input-event queue. It
+// follows the same functional-option shape as mado's WindowOptions, but
+// lives here rather than as a mado.Option: those are
+// `func(unit.Metric, *mado.Config)`, and mado.Config is a pre-existing
+// struct this change doesn't own. Until a field is added there upstream,
+// set DefaultEventQueueOptions (applied by callbacks.SetWindow) or call
+// ApplyEventQueueOptions directly.
+type EventQueueOption func(*callbacks)
+
+// DefaultEventQueueOptions is applied to every window's callbacks by
+// SetWindow, before the window processes any event.
+var DefaultEventQueueOptions []EventQueueOption
+
+// WithEventQueueHighWater overrides defaultEventQueueHighWater for one
+// window's callbacks.
+func WithEventQueueHighWater(n int) EventQueueOption {
+	return func(c *callbacks) {
+		c.eventQueueHighWater = n
+	}
+}
+
+// ApplyEventQueueOptions applies opts to c.
+func (c *callbacks) ApplyEventQueueOptions(opts ...EventQueueOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// pointerMoveHistoryLimit bounds how many timestamps enqueue retains per
+// pointer across collapsed pointer.Move events, for gesture recognizers
+// that need more than just the latest sample.
+const pointerMoveHistoryLimit = 8
+
+// enqueue appends e to the pending event queue, coalescing it with an
+// already-queued event where that's safe and cheap:
+//
+//   - a mado.WakeupEvent is dropped while another one is still queued,
+//     adjacent or not, so at most one is pending at a time. This is also what
+//     coalesces a resize: this driver layer has no separate resize
+//     event, it signals "something changed, go re-read window state" via
+//     a WakeupEvent and UpdateState() pulls the new size from the
+//     platform window afterwards, so collapsing a flood of WakeupEvents
+//     during a resize drag is exactly collapsing the resize;
+//   - consecutive pointer.Move events for the same pointer collapse to
+//     the latest position, keeping the superseded timestamps in
+//     pointerMoveHistory;
+//   - key.EditEvents that continue the same IME composing region
+//     collapse to the latest replacement.
+//
+// system.DestroyEvent and key.FocusEvent always go to a priority lane
+// (see dequeue) so a sustained stream of the events above can never
+// starve them. Once the queue reaches highWater(), further low-priority
+// events are dropped and logged rather than queued.
+func (c *callbacks) enqueue(e event.Event) {
+	switch e := e.(type) {
+	case system.DestroyEvent, key.FocusEvent:
+		c.priorityEvents = append(c.priorityEvents, e)
+		return
+	case mado.WakeupEvent:
+		for _, pending := range c.waitEvents {
+			if _, ok := pending.(mado.WakeupEvent); ok {
+				return
+			}
+		}
+	case pointer.Event:
+		if e.Kind == pointer.Move {
+			if i := c.indexOfPointerMove(e.PointerID); i >= 0 {
+				prev := c.waitEvents[i].(pointer.Event)
+				c.recordPointerMove(prev)
+				c.waitEvents[i] = e
+				return
+			}
+		}
+	case key.EditEvent:
+		if i := c.indexOfComposingEdit(e.Range); i >= 0 {
+			c.waitEvents[i] = e
+			return
+		}
+	}
+	if len(c.waitEvents) >= c.highWater() {
+		log.Printf("mado: dropping %T, event queue exceeded %d entries", e, c.highWater())
+		return
+	}
+	c.waitEvents = append(c.waitEvents, e)
+}
+
+// dequeue removes and returns the next event to process, draining
+// priorityEvents before waitEvents.
+func (c *callbacks) dequeue() (event.Event, bool) {
+	if len(c.priorityEvents) > 0 {
+		e := c.priorityEvents[0]
+		copy(c.priorityEvents, c.priorityEvents[1:])
+		c.priorityEvents = c.priorityEvents[:len(c.priorityEvents)-1]
+		return e, true
+	}
+	if len(c.waitEvents) > 0 {
+		e := c.waitEvents[0]
+		copy(c.waitEvents, c.waitEvents[1:])
+		c.waitEvents = c.waitEvents[:len(c.waitEvents)-1]
+		return e, true
+	}
+	return nil, false
+}
+
+func (c *callbacks) highWater() int {
+	if c.eventQueueHighWater > 0 {
+		return c.eventQueueHighWater
+	}
+	return defaultEventQueueHighWater
+}
+
+// indexOfPointerMove returns the index of the tail of waitEvents if it's a
+// still-queued pointer.Move for id. Only the tail is considered so
+// coalescing never reorders events relative to anything already queued
+// ahead of it.
+func (c *callbacks) indexOfPointerMove(id pointer.ID) int {
+	i := len(c.waitEvents) - 1
+	if i < 0 {
+		return -1
+	}
+	p, ok := c.waitEvents[i].(pointer.Event)
+	if !ok || p.PointerID != id || p.Kind != pointer.Move {
+		return -1
+	}
+	return i
+}
+
+// indexOfComposingEdit returns the index of the tail of waitEvents if it's
+// a still-queued key.EditEvent whose range is immediately extended by r,
+// i.e. the IME is still composing the same region.
+func (c *callbacks) indexOfComposingEdit(r key.Range) int {
+	i := len(c.waitEvents) - 1
+	if i < 0 {
+		return -1
+	}
+	edit, ok := c.waitEvents[i].(key.EditEvent)
+	if !ok || (edit.Range.End != r.Start && edit.Range != r) {
+		return -1
+	}
+	return i
+}
+
+func (c *callbacks) recordPointerMove(e pointer.Event) {
+	if c.pointerMoveHistory == nil {
+		c.pointerMoveHistory = make(map[pointer.ID][]time.Duration)
+	}
+	h := append(c.pointerMoveHistory[e.PointerID], e.Time)
+	if len(h) > pointerMoveHistoryLimit {
+		h = h[len(h)-pointerMoveHistoryLimit:]
+	}
+	c.pointerMoveHistory[e.PointerID] = h
+}
+
+// PointerMoveHistory returns the timestamps of recent pointer.Move events
+// for id that were coalesced away by enqueue, oldest first. Gesture
+// recognizers that need more than the latest sample can use this instead
+// of relying on every intermediate move having reached ProcessEvent.
+func (c *callbacks) PointerMoveHistory(id pointer.ID) []time.Duration {
+	return c.pointerMoveHistory[id]
+}
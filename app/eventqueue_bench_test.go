@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kanryu/mado/io/pointer"
+)
+
+// BenchmarkCallbacksEventPointerStorm simulates a synthetic 10k-events/sec
+// pointer stream arriving faster than the real Event loop could drain it,
+// and counts how many dequeue() calls it takes to drain the result.
+//
+// Event(e) itself is just enqueue(e) followed by draining dequeue() in a
+// loop, calling (*Window).ProcessEvent once per dequeue; ProcessEvent
+// lives on *Window and needs a fully-initialized driver/window pair this
+// package-level benchmark can't construct in isolation, so dequeue count
+// is used directly as an exact stand-in for ProcessEvent call count.
+func BenchmarkCallbacksEventPointerStorm(b *testing.B) {
+	const eventsPerBatch = 10000
+	for i := 0; i < b.N; i++ {
+		c := &callbacks{}
+		for j := 0; j < eventsPerBatch; j++ {
+			c.enqueue(pointer.Event{
+				Kind:      pointer.Move,
+				PointerID: 1,
+				Time:      time.Duration(j) * 100 * time.Microsecond,
+			})
+		}
+		var processEventCalls int
+		for _, ok := c.dequeue(); ok; _, ok = c.dequeue() {
+			processEventCalls++
+		}
+		if processEventCalls != 1 {
+			b.Fatalf("want coalescing to reduce %d pointer.Moves to a single ProcessEvent call, got %d", eventsPerBatch, processEventCalls)
+		}
+	}
+}
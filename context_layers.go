@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package mado
+
+import "fmt"
+
+// GraphicsDisplay owns the graphics resources a backend creates once per
+// process and shares across every window it drives: the D3D11/D3D12
+// device, the EGL display, an NSOpenGLContext share group, the Vulkan
+// instance, and so on. Backends create their GraphicsDisplay the first
+// time any window asks for one, and release it once the last Surface
+// referencing it is gone.
+type GraphicsDisplay interface {
+	// Configs lists the GraphicsConfigs this Display can create a Surface
+	// for.
+	Configs() []GraphicsConfig
+	Release()
+}
+
+// GraphicsConfig describes a selectable pixel format / feature level /
+// MSAA / sRGB combination. GraphicsConfigs are queryable and selectable
+// up front, before a window (and its Surface) is created.
+type GraphicsConfig interface {
+	fmt.Stringer
+}
+
+// Surface is the per-window, cheap-to-create-and-destroy half of what a
+// single monolithic Context used to be: a swapchain or framebuffer bound
+// to one window and backed by a shared GraphicsDisplay. Surface is a
+// Context, so existing callers that only know about Context keep working
+// unchanged.
+type Surface interface {
+	Context
+}
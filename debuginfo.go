@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package mado
+
+// GraphicsLibrary identifies the underlying graphics API a Context is
+// actually running on.
+type GraphicsLibrary int
+
+const (
+	GraphicsLibraryUnknown GraphicsLibrary = iota
+	GraphicsLibraryOpenGL
+	GraphicsLibraryOpenGLES
+	GraphicsLibraryDirect3D11
+	GraphicsLibraryDirect3D12
+	GraphicsLibraryMetal
+	GraphicsLibraryVulkan
+)
+
+func (g GraphicsLibrary) String() string {
+	switch g {
+	case GraphicsLibraryOpenGL:
+		return "OpenGL"
+	case GraphicsLibraryOpenGLES:
+		return "OpenGLES"
+	case GraphicsLibraryDirect3D11:
+		return "Direct3D11"
+	case GraphicsLibraryDirect3D12:
+		return "Direct3D12"
+	case GraphicsLibraryMetal:
+		return "Metal"
+	case GraphicsLibraryVulkan:
+		return "Vulkan"
+	default:
+		return "Unknown"
+	}
+}
+
+// DebugInfo carries introspection data about the graphics backend
+// currently driving a Window.
+type DebugInfo struct {
+	// GraphicsLibrary is the API the active Context was created with.
+	GraphicsLibrary GraphicsLibrary
+	// Adapter is the GPU adapter/device name, e.g. IDXGIAdapter::GetDesc
+	// or GL_RENDERER.
+	Adapter string
+	// Device is additional backend/driver identification, e.g.
+	// GL_VERSION on GL paths.
+	Device string
+}
+
+// DebugContext is implemented by Contexts that can report DebugInfo about
+// themselves. A Context that doesn't implement it is reported as
+// GraphicsLibraryUnknown by (*Window).ReadDebugInfo.
+type DebugContext interface {
+	DebugInfo() DebugInfo
+}
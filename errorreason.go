@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package mado
+
+// ErrorReason classifies a platform/driver error for compat layers (such
+// as package glfw) to translate into their own error-code space without
+// mado depending on them.
+type ErrorReason int
+
+const (
+	// ErrorReasonPlatform is a platform-specific error that doesn't fit
+	// any of the more specific reasons below.
+	ErrorReasonPlatform ErrorReason = iota
+	// ErrorReasonAPIUnavailable means the requested graphics API (or
+	// feature level) isn't available, e.g. a GPU device failed to be
+	// created at every feature level a backend tried.
+	ErrorReasonAPIUnavailable
+	// ErrorReasonFormatUnavailable means the requested pixel format/swap
+	// chain configuration isn't supported.
+	ErrorReasonFormatUnavailable
+)
+
+// ErrorHandler, if non-nil, is invoked by drivers when a platform or
+// window-creation error occurs, so compat layers like glfw can surface it
+// through their own error-callback machinery without mado importing them.
+var ErrorHandler func(reason ErrorReason, desc string)
+
+// ReportError invokes ErrorHandler if one is installed.
+func ReportError(reason ErrorReason, desc string) {
+	if ErrorHandler != nil {
+		ErrorHandler(reason, desc)
+	}
+}
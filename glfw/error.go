@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+
+	"github.com/kanryu/mado"
 )
 
 // ErrorCode corresponds to an error code.
@@ -125,29 +128,88 @@ func (e *Error) Error() string {
 // Holds the value of the last error.
 var lastError = make(chan *Error, 1)
 
-// //export goErrorCB
-// func goErrorCB(code C.int, desc *C.char) {
-// 	flushErrors()
-// 	err := &Error{ErrorCode(code), C.GoString(desc)}
-// 	select {
-// 	case lastError <- err:
-// 	default:
-// 		fmt.Fprintln(os.Stderr, "go-gl/glfw: internal error: an uncaught error has occurred:", err)
-// 		fmt.Fprintln(os.Stderr, "go-gl/glfw: Please report this in the Go package issue tracker.")
-// 	}
-// }
-
-// Set the glfw callback internally
+// errorCallback holds the user-installed error callback, if any. It is
+// guarded by errorCallbackMu since it can be set from any goroutine while
+// goErrorCB may be invoked from the platform/driver layer concurrently.
+var (
+	errorCallbackMu sync.Mutex
+	errorCallback   func(ErrorCode, string)
+)
+
+// SetErrorCallback sets the error callback, which is called with an error
+// code and a human-readable description each time a GLFW-compat error
+// occurs. It returns the previously set callback, or nil if none was set.
+//
+// This matches the signature of glfw.SetErrorCallback in go-gl/glfw3.
+func SetErrorCallback(cbfun func(ErrorCode, string)) func(ErrorCode, string) {
+	errorCallbackMu.Lock()
+	defer errorCallbackMu.Unlock()
+	previous := errorCallback
+	errorCallback = cbfun
+	return previous
+}
+
+// init registers goErrorCB with mado.ErrorHandler, so platform-init and
+// window-creation failures from the driver layer reach
+// lastError/acceptError/panicError and any installed SetErrorCallback.
 func init() {
-	// C.glfwSetErrorCallbackCB()
+	mado.ErrorHandler = func(reason mado.ErrorReason, desc string) {
+		goErrorCB(errorCodeForReason(reason), desc)
+	}
+}
+
+// errorCodeForReason maps the driver-agnostic mado.ErrorReason to the
+// GLFW-compat ErrorCode closest to what go-gl/glfw3 would report for the
+// same failure.
+func errorCodeForReason(reason mado.ErrorReason) ErrorCode {
+	switch reason {
+	case mado.ErrorReasonAPIUnavailable:
+		return apiUnavailable
+	case mado.ErrorReasonFormatUnavailable:
+		return formatUnavailable
+	default:
+		return platformError
+	}
+}
+
+// goErrorCB is the single entry point errors from the underlying mado
+// driver/window layer are reported through. It always buffers the error
+// in lastError so acceptError/panicError keep working, and, if a callback
+// is installed, also delivers it there.
+func goErrorCB(code ErrorCode, desc string) {
+	err := &Error{code, desc}
+	select {
+	case lastError <- err:
+	default:
+		fmt.Fprintln(os.Stderr, "go-gl/glfw: internal error: an uncaught error has occurred:", err)
+		fmt.Fprintln(os.Stderr, "go-gl/glfw: Please report this in the Go package issue tracker.")
+	}
+
+	errorCallbackMu.Lock()
+	cb := errorCallback
+	errorCallbackMu.Unlock()
+	if cb != nil {
+		cb(code, desc)
+	}
 }
 
 // flushErrors is called by Terminate before it actually calls C.glfwTerminate,
-// this ensures that any uncaught errors buffered in lastError are printed
+// this ensures that any errors still buffered in lastError are drained and,
+// if a callback is installed, delivered to it; otherwise they are printed
 // before the program exits.
 func flushErrors() {
-	err := fetchError()
-	if err != nil {
+	for {
+		err := fetchError()
+		if err == nil {
+			return
+		}
+		errorCallbackMu.Lock()
+		cb := errorCallback
+		errorCallbackMu.Unlock()
+		if cb != nil {
+			cb(err.Code, err.Desc)
+			continue
+		}
 		fmt.Fprintln(os.Stderr, "go-gl/glfw: internal error: an uncaught error has occurred:", err)
 		fmt.Fprintln(os.Stderr, "go-gl/glfw: Please report this in the Go package issue tracker.")
 	}